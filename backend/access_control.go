@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// signClaim produces an HMAC-SHA256 signature over token+email, keyed by
+// the server-wide CLAIM_SIGNING_SECRET. It is embedded in the per-recipient
+// claim link CreateShareHandler issues for AllowedRecipients shares, so a
+// leaked share token alone doesn't let a non-recipient view the payload.
+func signClaim(secret, token, email string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token + ":" + email))
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyClaim reports whether sig is a valid signature for token+email.
+func verifyClaim(secret, token, email, sig string) bool {
+	expected := signClaim(secret, token, email)
+	return hmac.Equal([]byte(expected), []byte(sig))
+}
+
+// emailAllowed reports whether email appears in allowed, case-insensitively.
+func emailAllowed(allowed []string, email string) bool {
+	for _, candidate := range allowed {
+		if strings.EqualFold(candidate, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address GetShareHandler checks AllowedCIDRs against:
+// r.RemoteAddr, or the first hop of the TRUSTED_PROXY_HEADER (e.g.
+// "X-Forwarded-For") when the deployment is configured to trust a
+// front-end proxy for that header.
+func clientIP(r *http.Request) string {
+	if header := os.Getenv("TRUSTED_PROXY_HEADER"); header != "" {
+		if value := r.Header.Get(header); value != "" {
+			if first := strings.TrimSpace(strings.Split(value, ",")[0]); first != "" {
+				return first
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ipAllowed reports whether ip falls within at least one of cidrs.
+func ipAllowed(cidrs []string, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
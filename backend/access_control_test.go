@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSignAndVerifyClaim(t *testing.T) {
+	sig := signClaim("secret", "token123", "user@example.com")
+
+	if !verifyClaim("secret", "token123", "user@example.com", sig) {
+		t.Fatal("verifyClaim rejected a signature it just produced")
+	}
+	if verifyClaim("secret", "token123", "other@example.com", sig) {
+		t.Fatal("verifyClaim accepted a signature for a different email")
+	}
+	if verifyClaim("wrong-secret", "token123", "user@example.com", sig) {
+		t.Fatal("verifyClaim accepted a signature made with a different secret")
+	}
+}
+
+func TestEmailAllowed(t *testing.T) {
+	allowed := []string{"Alice@example.com", "bob@example.com"}
+
+	if !emailAllowed(allowed, "alice@example.com") {
+		t.Fatal("emailAllowed should be case-insensitive")
+	}
+	if emailAllowed(allowed, "carol@example.com") {
+		t.Fatal("emailAllowed accepted an email not in the allow-list")
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	cidrs := []string{"10.0.0.0/8", "192.168.1.0/24"}
+
+	if !ipAllowed(cidrs, "10.1.2.3") {
+		t.Fatal("ipAllowed rejected an address within 10.0.0.0/8")
+	}
+	if !ipAllowed(cidrs, "192.168.1.42") {
+		t.Fatal("ipAllowed rejected an address within 192.168.1.0/24")
+	}
+	if ipAllowed(cidrs, "172.16.0.1") {
+		t.Fatal("ipAllowed accepted an address outside every CIDR")
+	}
+	if ipAllowed(cidrs, "not-an-ip") {
+		t.Fatal("ipAllowed accepted an unparseable address")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Run("falls back to RemoteAddr without a trusted proxy header", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.5:54321"
+		req.Header.Set("X-Forwarded-For", "198.51.100.9")
+
+		if got := clientIP(req); got != "203.0.113.5" {
+			t.Fatalf("clientIP = %q, want RemoteAddr host %q (TRUSTED_PROXY_HEADER unset)", got, "203.0.113.5")
+		}
+	})
+
+	t.Run("trusts the configured proxy header's first hop", func(t *testing.T) {
+		t.Setenv("TRUSTED_PROXY_HEADER", "X-Forwarded-For")
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.5:54321"
+		req.Header.Set("X-Forwarded-For", "198.51.100.9, 203.0.113.5")
+
+		if got := clientIP(req); got != "198.51.100.9" {
+			t.Fatalf("clientIP = %q, want first hop %q", got, "198.51.100.9")
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// adminAuthMiddleware builds per-route middleware requiring a
+// "Bearer <token>" Authorization header matching token (the ADMIN_TOKEN
+// environment variable). If token is empty, the admin API is treated as
+// unconfigured and always rejected, rather than accepting an empty bearer
+// token.
+func adminAuthMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.Error(w, "Admin API is not configured", http.StatusServiceUnavailable)
+				return
+			}
+
+			const prefix = "Bearer "
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, prefix) ||
+				!hmac.Equal([]byte(strings.TrimPrefix(auth, prefix)), []byte(token)) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminShareSummary is what GET /api/admin/shares returns per share: enough
+// for an operator to audit what's outstanding, with secrets (password hash,
+// encrypted payload) left out.
+type AdminShareSummary struct {
+	Token       string     `json:"token"`
+	ServiceName string     `json:"service_name"`
+	Username    string     `json:"username"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	Scope       Scope      `json:"scope"`
+	Viewed      bool       `json:"viewed"`
+	ViewedAt    *time.Time `json:"viewed_at,omitempty"`
+	ViewCount   int        `json:"view_count"`
+	MaxViews    int        `json:"max_views,omitempty"`
+	HasPassword bool       `json:"has_password"`
+}
+
+// AdminSharesHandler handles GET /api/admin/shares, exposing
+// ShareStore.GetAll through an authenticated endpoint.
+func AdminSharesHandler(store ShareStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		all, err := store.GetAll()
+		if err != nil {
+			log.Printf("Error listing shares: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		summaries := make([]AdminShareSummary, 0, len(all))
+		for token, data := range all {
+			summaries = append(summaries, AdminShareSummary{
+				Token:       token,
+				ServiceName: data.ServiceName,
+				Username:    data.Username,
+				CreatedAt:   data.CreatedAt,
+				ExpiresAt:   data.ExpiresAt,
+				Scope:       data.Scope,
+				Viewed:      data.Viewed,
+				ViewedAt:    data.ViewedAt,
+				ViewCount:   data.ViewCount,
+				MaxViews:    data.MaxViews,
+				HasPassword: data.HasPassword(),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summaries)
+	}
+}
+
+// AdminAuditHandler handles GET /api/admin/audit, optionally filtered by
+// the "since"/"until" (RFC3339), "service", and "event" query parameters.
+func AdminAuditHandler(auditLog *AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter := AuditFilter{
+			Service: r.URL.Query().Get("service"),
+			Event:   r.URL.Query().Get("event"),
+		}
+
+		if since := r.URL.Query().Get("since"); since != "" {
+			t, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				http.Error(w, "Invalid since parameter, expected RFC3339", http.StatusBadRequest)
+				return
+			}
+			filter.Since = t
+		}
+		if until := r.URL.Query().Get("until"); until != "" {
+			t, err := time.Parse(time.RFC3339, until)
+			if err != nil {
+				http.Error(w, "Invalid until parameter, expected RFC3339", http.StatusBadRequest)
+				return
+			}
+			filter.Until = t
+		}
+
+		events, err := auditLog.Query(filter)
+		if err != nil {
+			log.Printf("Error querying audit log: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(events)
+	}
+}
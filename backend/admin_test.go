@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newTestAdminRouter(t *testing.T, adminToken string) (chi.Router, ShareStore, *AuditLog) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	auditLog, err := NewAuditLog(t.TempDir() + "/audit.log")
+	if err != nil {
+		t.Fatalf("NewAuditLog: %v", err)
+	}
+
+	router := chi.NewRouter()
+	router.Route("/api/admin", func(admin chi.Router) {
+		admin.Use(adminAuthMiddleware(adminToken))
+		admin.Get("/shares", AdminSharesHandler(store))
+		admin.Get("/audit", AdminAuditHandler(auditLog))
+	})
+	return router, store, auditLog
+}
+
+func TestAdminAuthMiddleware_Unconfigured(t *testing.T) {
+	router, _, _ := newTestAdminRouter(t, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/shares", nil)
+	req.Header.Set("Authorization", "Bearer anything")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d (admin API unconfigured)", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestAdminAuthMiddleware_MissingOrWrongToken(t *testing.T) {
+	router, _, _ := newTestAdminRouter(t, "s3cret")
+
+	noAuth := httptest.NewRequest(http.MethodGet, "/api/admin/shares", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, noAuth)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no Authorization header: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	wrongToken := httptest.NewRequest(http.MethodGet, "/api/admin/shares", nil)
+	wrongToken.Header.Set("Authorization", "Bearer wrong-token")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, wrongToken)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong bearer token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAdminAuthMiddleware_CorrectToken(t *testing.T) {
+	router, _, _ := newTestAdminRouter(t, "s3cret")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/shares", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestAdminSharesHandler_ListsWithoutSecrets(t *testing.T) {
+	router, store, _ := newTestAdminRouter(t, "s3cret")
+
+	if err := store.Add("tok", &ShareData{
+		ServiceName:  "example-service",
+		PasswordHash: "should-not-be-exposed",
+	}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/shares", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "should-not-be-exposed") {
+		t.Fatal("AdminSharesHandler leaked the password hash into its response")
+	}
+	if !strings.Contains(rec.Body.String(), "example-service") {
+		t.Fatal("AdminSharesHandler response is missing the expected share")
+	}
+}
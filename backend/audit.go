@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event names recorded to the audit log.
+const (
+	AuditShareCreated = "share_created"
+	AuditShareViewed  = "share_viewed"
+	AuditAccessDenied = "access_denied"
+	AuditUnlockFailed = "unlock_failed"
+	AuditShareDeleted = "share_deleted"
+	AuditCleanup      = "cleanup"
+)
+
+// Outcomes recorded alongside an audit event.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// defaultAuditRotateBytes is the size an audit log file may reach before
+// AuditLog rotates it out to a single ".1" backup.
+const defaultAuditRotateBytes = 10 * 1024 * 1024
+
+// AuditEvent is one line of the append-only audit log.
+type AuditEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Event       string    `json:"event"`
+	Outcome     string    `json:"outcome"`
+	TokenPrefix string    `json:"token_prefix,omitempty"`
+	ServiceName string    `json:"service_name,omitempty"`
+	SourceIP    string    `json:"source_ip,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
+}
+
+// AuditLog is an append-only, JSON-lines record of share lifecycle events,
+// rotated by size so it doesn't grow unbounded. log.Printf gives operators
+// a scrollback of what happened; this gives them something they can filter
+// and build compliance reporting on top of.
+type AuditLog struct {
+	mu          sync.Mutex
+	path        string
+	rotateBytes int64
+	file        *os.File
+}
+
+// NewAuditLog opens (creating if necessary) the audit log file at path.
+func NewAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &AuditLog{path: path, rotateBytes: defaultAuditRotateBytes, file: file}, nil
+}
+
+// Record appends evt to the log, stamping Timestamp if unset. Failures are
+// logged rather than propagated: an audit write should never block or fail
+// the request it's describing.
+func (l *AuditLog) Record(evt AuditEvent) {
+	if evt.Timestamp.IsZero() {
+		evt.Timestamp = time.Now()
+	}
+
+	encoded, err := json.Marshal(evt)
+	if err != nil {
+		log.Printf("Error encoding audit event: %v", err)
+		return
+	}
+	encoded = append(encoded, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.rotateIfNeeded(int64(len(encoded))); err != nil {
+		log.Printf("Error rotating audit log: %v", err)
+	}
+
+	if _, err := l.file.Write(encoded); err != nil {
+		log.Printf("Error writing audit event: %v", err)
+	}
+}
+
+// rotateIfNeeded renames the current file to a single ".1" backup
+// (replacing any previous one) once writing n more bytes would exceed
+// rotateBytes, then reopens a fresh file at path. Caller must hold l.mu.
+func (l *AuditLog) rotateIfNeeded(n int64) error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size()+n <= l.rotateBytes {
+		return nil
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	backup := l.path + ".1"
+	os.Remove(backup)
+	if err := os.Rename(l.path, backup); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	l.file = file
+	return nil
+}
+
+// AuditFilter narrows the events Query returns for GET /api/admin/audit.
+// Zero-valued fields are not applied.
+type AuditFilter struct {
+	Since   time.Time
+	Until   time.Time
+	Service string
+	Event   string
+}
+
+// matches reports whether evt satisfies every set field of f.
+func (f AuditFilter) matches(evt AuditEvent) bool {
+	if !f.Since.IsZero() && evt.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && evt.Timestamp.After(f.Until) {
+		return false
+	}
+	if f.Service != "" && evt.ServiceName != f.Service {
+		return false
+	}
+	if f.Event != "" && evt.Event != f.Event {
+		return false
+	}
+	return true
+}
+
+// Query reads every event matching filter from the log file and its
+// rotated backup, oldest first.
+func (l *AuditLog) Query(filter AuditFilter) ([]AuditEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []AuditEvent
+	for _, path := range []string{l.path + ".1", l.path} {
+		read, err := readAuditFile(path, filter)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, read...)
+	}
+	return events, nil
+}
+
+func readAuditFile(path string, filter AuditFilter) ([]AuditEvent, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var evt AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			continue
+		}
+		if filter.matches(evt) {
+			events = append(events, evt)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// tokenPrefix returns a short, non-sensitive prefix of token suitable for
+// logging and audit events.
+func tokenPrefix(token string) string {
+	if len(token) <= 10 {
+		return token
+	}
+	return token[:10] + "..."
+}
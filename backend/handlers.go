@@ -2,13 +2,21 @@ package main
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"strings"
+	"net/mail"
+	"net/url"
+	"os"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // generateSecureToken generates a cryptographically secure random token
@@ -20,14 +28,36 @@ func generateSecureToken(length int) (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
+// shareUnlockDigest combines the password, per-share salt, and server-wide
+// SHARE_PASSWORD_SALT pepper into a fixed-size SHA-256 digest before
+// handing it to bcrypt, which only examines its input's first 72 bytes.
+// Without this, a long password plus the (~24 byte) salt and pepper could
+// silently push the salt/pepper past that boundary and drop them from the
+// hash.
+func shareUnlockDigest(password, salt string) []byte {
+	sum := sha256.Sum256([]byte(password + salt + os.Getenv("SHARE_PASSWORD_SALT")))
+	return sum[:]
+}
+
+// hashSharePassword derives a bcrypt hash from a share's unlock password, a
+// per-share random salt, and the server-wide SHARE_PASSWORD_SALT pepper.
+func hashSharePassword(password, salt string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(shareUnlockDigest(password, salt), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// verifySharePassword checks a candidate password against the stored hash.
+func verifySharePassword(password, salt, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), shareUnlockDigest(password, salt))
+	return err == nil
+}
+
 // CreateShareHandler handles POST /api/share
-func CreateShareHandler(store *ShareStore, baseURL string) http.HandlerFunc {
+func CreateShareHandler(store ShareStore, baseURL string, auditLog *AuditLog) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		var req ShareRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -40,12 +70,53 @@ func CreateShareHandler(store *ShareStore, baseURL string) http.HandlerFunc {
 			return
 		}
 
+		// UnlockGuard's rate limiting and issued unlock credentials are kept
+		// in-process (see unlock_guard.go), so a password-protected share
+		// only works reliably when every request for it can land on the
+		// same instance. Refuse to create one whenever STORE_BACKEND selects
+		// anything but the in-memory store, since those backends let the rest
+		// of the share's state outlive this process or be shared by instances
+		// behind a load balancer, rather than silently failing unlocks later.
+		if req.Password != "" && StoreBackendName() != "memory" {
+			http.Error(w, "Password-protected shares require STORE_BACKEND=memory; unlock credentials and rate limiting are process-local", http.StatusBadRequest)
+			return
+		}
+
 		// Set default expiration to 24 hours if not specified
 		expirationHours := req.ExpirationHours
 		if expirationHours <= 0 {
 			expirationHours = 24
 		}
 
+		// Recipients get a normalized, validated address and, once emails
+		// are involved, require a CLAIM_SIGNING_SECRET so the per-recipient
+		// claim links generated below can actually be signed.
+		var allowedRecipients []string
+		for _, email := range req.AllowedRecipients {
+			addr, err := mail.ParseAddress(email)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid recipient email: %s", email), http.StatusBadRequest)
+				return
+			}
+			allowedRecipients = append(allowedRecipients, addr.Address)
+		}
+
+		claimSecret := os.Getenv("CLAIM_SIGNING_SECRET")
+		if len(allowedRecipients) > 0 && claimSecret == "" {
+			log.Printf("CLAIM_SIGNING_SECRET not set; cannot issue recipient claim links")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		var allowedCIDRs []string
+		for _, cidr := range req.AllowedCIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid CIDR: %s", cidr), http.StatusBadRequest)
+				return
+			}
+			allowedCIDRs = append(allowedCIDRs, cidr)
+		}
+
 		// Generate secure token (32 bytes = ~43 characters in base64)
 		token, err := generateSecureToken(32)
 		if err != nil {
@@ -54,6 +125,35 @@ func CreateShareHandler(store *ShareStore, baseURL string) http.HandlerFunc {
 			return
 		}
 
+		// Default to one_time scope for backwards-compatible requests.
+		scope := req.Scope
+		if scope == "" {
+			scope = ScopeOneTime
+		}
+		if !scope.IsValid() {
+			http.Error(w, "Invalid scope", http.StatusBadRequest)
+			return
+		}
+
+		maxViews := req.MaxViews
+		if scope == ScopeMultiView {
+			if maxViews <= 0 {
+				http.Error(w, "max_views is required for multi_view scope", http.StatusBadRequest)
+				return
+			}
+		} else {
+			maxViews = 0
+		}
+
+		// With more than one AllowedRecipients entry, anything but
+		// multi_view (with enough views for every recipient) would let
+		// the first recipient silently consume the only view, leaving
+		// the rest unable to tell their link apart from an expired one.
+		if len(allowedRecipients) > 1 && (scope != ScopeMultiView || maxViews < len(allowedRecipients)) {
+			http.Error(w, "multiple allowed_recipients requires scope=multi_view with max_views >= number of recipients", http.StatusBadRequest)
+			return
+		}
+
 		// Create share data
 		now := time.Now()
 		shareData := &ShareData{
@@ -63,46 +163,160 @@ func CreateShareHandler(store *ShareStore, baseURL string) http.HandlerFunc {
 			CreatedAt:         now,
 			ExpiresAt:         now.Add(time.Duration(expirationHours) * time.Hour),
 			Viewed:            false,
+			Scope:             scope,
+			MaxViews:          maxViews,
+			AllowedRecipients: allowedRecipients,
+			AllowedCIDRs:      allowedCIDRs,
+		}
+
+		// write-scope shares get a second token, paired to the share but
+		// never handed to the recipient, that the creator later uses to
+		// fetch the recipient's reply.
+		if scope == ScopeWrite {
+			replyToken, err := generateSecureToken(24)
+			if err != nil {
+				log.Printf("Error generating reply token: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			shareData.ReplyToken = replyToken
+		}
+
+		// Optionally protect the share with a password. Only the bcrypt
+		// hash (salted with a per-share random value plus the server-wide
+		// SHARE_PASSWORD_SALT pepper) is ever persisted.
+		if req.Password != "" {
+			salt, err := generateSecureToken(16)
+			if err != nil {
+				log.Printf("Error generating password salt: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			hash, err := hashSharePassword(req.Password, salt)
+			if err != nil {
+				log.Printf("Error hashing share password: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			shareData.PasswordSalt = salt
+			shareData.PasswordHash = hash
 		}
 
 		// Store the share
-		store.Add(token, shareData)
+		if err := store.Add(token, shareData); err != nil {
+			log.Printf("Error storing share: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
-		// Create response
+		// Create response. Recipient-restricted shares get a distinct signed
+		// claim link per recipient instead of (or alongside) the plain
+		// ShareURL, since the bare token isn't enough to view them.
 		shareURL := fmt.Sprintf("%s/share/%s", baseURL, token)
 		response := ShareResponse{
-			Token:     token,
-			ShareURL:  shareURL,
-			ExpiresAt: shareData.ExpiresAt,
+			Token:      token,
+			ShareURL:   shareURL,
+			ExpiresAt:  shareData.ExpiresAt,
+			ReplyToken: shareData.ReplyToken,
+		}
+
+		if len(allowedRecipients) > 0 {
+			links := make([]ClaimLink, 0, len(allowedRecipients))
+			for _, email := range allowedRecipients {
+				sig := signClaim(claimSecret, token, email)
+				links = append(links, ClaimLink{
+					Email: email,
+					URL:   fmt.Sprintf("%s?email=%s&sig=%s", shareURL, url.QueryEscape(email), url.QueryEscape(sig)),
+				})
+			}
+			response.ClaimLinks = links
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
-		
-		log.Printf("Created share: token=%s, service=%s, expires=%s", 
+
+		log.Printf("Created share: token=%s, service=%s, expires=%s",
 			token[:10]+"...", req.ServiceName, shareData.ExpiresAt.Format(time.RFC3339))
+
+		auditLog.Record(AuditEvent{
+			Event:       AuditShareCreated,
+			Outcome:     OutcomeSuccess,
+			TokenPrefix: tokenPrefix(token),
+			ServiceName: req.ServiceName,
+			SourceIP:    clientIP(r),
+			UserAgent:   r.UserAgent(),
+		})
 	}
 }
 
-// GetShareHandler handles GET /api/share/:token
-func GetShareHandler(store *ShareStore) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
+// authorizeShareRequest enforces the same AllowedCIDRs/AllowedRecipients
+// gate for every request that touches a share's protected contents —
+// currently GetShareHandler (the payload itself) and UnlockShareHandler
+// (verifying the unlock password) — so a disallowed caller can't use the
+// unlock endpoint as a password-guessing oracle just because it checked
+// fewer things than the retrieval endpoint. On success it returns the
+// verified recipient email (empty when AllowedRecipients isn't set); on
+// failure it has already written the 403 response and recorded an
+// AuditAccessDenied event, and the caller should return immediately.
+func authorizeShareRequest(w http.ResponseWriter, r *http.Request, token string, shareData *ShareData, auditLog *AuditLog) (recipientEmail string, ok bool) {
+	deny := func(message, detail string) {
+		auditLog.Record(AuditEvent{
+			Event:       AuditAccessDenied,
+			Outcome:     OutcomeFailure,
+			TokenPrefix: tokenPrefix(token),
+			ServiceName: shareData.ServiceName,
+			SourceIP:    clientIP(r),
+			UserAgent:   r.UserAgent(),
+			Detail:      detail,
+		})
+		http.Error(w, message, http.StatusForbidden)
+	}
+
+	// Source-IP restricted shares reject any request whose client address
+	// (see clientIP) doesn't fall within AllowedCIDRs.
+	if len(shareData.AllowedCIDRs) > 0 && !ipAllowed(shareData.AllowedCIDRs, clientIP(r)) {
+		deny("Your network address is not authorized to view this share", "source IP not in AllowedCIDRs")
+		return "", false
+	}
+
+	// Recipient-restricted shares require a signed claim (email + sig query
+	// parameters) instead of the bare token, so a leaked link can't be
+	// claimed by anyone else.
+	if len(shareData.AllowedRecipients) > 0 {
+		email := r.URL.Query().Get("email")
+		sig := r.URL.Query().Get("sig")
+		secret := os.Getenv("CLAIM_SIGNING_SECRET")
+
+		if email == "" || sig == "" || secret == "" ||
+			!emailAllowed(shareData.AllowedRecipients, email) ||
+			!verifyClaim(secret, token, email, sig) {
+			deny("You are not an authorized recipient of this share", "recipient claim missing, unrecognized, or signature mismatch")
+			return "", false
 		}
+		return email, true
+	}
 
-		// Extract token from path
-		path := strings.TrimPrefix(r.URL.Path, "/api/share/")
-		token := path
+	return "", true
+}
 
+// GetShareHandler handles GET /api/share/:token
+func GetShareHandler(store ShareStore, guard *UnlockGuard, auditLog *AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := chi.URLParam(r, "token")
 		if token == "" {
 			http.Error(w, "Token required", http.StatusBadRequest)
 			return
 		}
 
 		// Retrieve share
-		shareData, exists := store.Get(token)
+		shareData, exists, err := store.Get(token)
+		if err != nil {
+			log.Printf("Error retrieving share: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 		if !exists {
 			http.Error(w, "Share not found or expired", http.StatusNotFound)
 			return
@@ -110,19 +324,59 @@ func GetShareHandler(store *ShareStore) http.HandlerFunc {
 
 		// Check if expired
 		if time.Now().After(shareData.ExpiresAt) {
-			store.Delete(token)
+			if err := store.Delete(token); err != nil {
+				log.Printf("Error deleting expired share: %v", err)
+			}
 			http.Error(w, "Share has expired", http.StatusGone)
 			return
 		}
 
-		// Check if already viewed (one-time use)
-		if shareData.Viewed {
+		// The verified recipient email (if any) is recorded in
+		// ShareData.ViewedBy as part of the RecordView call below.
+		recipientEmail, ok := authorizeShareRequest(w, r, token, shareData, auditLog)
+		if !ok {
+			return
+		}
+
+		// Password-protected shares require a valid unlock credential
+		// (obtained from POST /api/share/:token/unlock) before the
+		// encrypted payload is released.
+		if shareData.HasPassword() {
+			credential := r.URL.Query().Get("unlock_token")
+			if credential == "" || !guard.Consume(token, credential) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"auth_required": true,
+					"error":         "a password is required to view this share",
+				})
+				return
+			}
+		}
+
+		// Atomically register the view and enforce the share's scope (see
+		// RecordView), so a share is never delivered more times than its
+		// scope allows under concurrent GETs.
+		viewed, err := store.RecordView(token, recipientEmail)
+		switch {
+		case errors.Is(err, ErrAlreadyViewed):
+			auditLog.Record(AuditEvent{
+				Event:       AuditShareViewed,
+				Outcome:     OutcomeFailure,
+				TokenPrefix: tokenPrefix(token),
+				ServiceName: viewed.ServiceName,
+				SourceIP:    clientIP(r),
+				UserAgent:   r.UserAgent(),
+				Detail:      "already viewed",
+			})
 			response := ShareRetrieveResponse{
 				EncryptedPassword: "",
-				ServiceName:       shareData.ServiceName,
-				Username:          shareData.Username,
-				CreatedAt:         shareData.CreatedAt,
-				ViewedAt:          shareData.ViewedAt,
+				ServiceName:       viewed.ServiceName,
+				Username:          viewed.Username,
+				CreatedAt:         viewed.CreatedAt,
+				ViewedAt:          viewed.ViewedAt,
+				Scope:             viewed.Scope,
+				ViewsRemaining:    viewsRemaining(viewed),
 			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusGone)
@@ -131,64 +385,285 @@ func GetShareHandler(store *ShareStore) http.HandlerFunc {
 				"details": response,
 			})
 			return
+		case errors.Is(err, ErrShareNotFound):
+			auditLog.Record(AuditEvent{
+				Event:       AuditShareViewed,
+				Outcome:     OutcomeFailure,
+				TokenPrefix: tokenPrefix(token),
+				SourceIP:    clientIP(r),
+				UserAgent:   r.UserAgent(),
+				Detail:      "share not found",
+			})
+			http.Error(w, "Share not found or expired", http.StatusNotFound)
+			return
+		case err != nil:
+			log.Printf("Error consuming share: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
 		}
 
-		// Mark as viewed and delete (one-time use)
-		store.MarkViewed(token)
-		
 		response := ShareRetrieveResponse{
-			EncryptedPassword: shareData.EncryptedPassword,
-			ServiceName:       shareData.ServiceName,
-			Username:          shareData.Username,
-			CreatedAt:         shareData.CreatedAt,
-			ViewedAt:          shareData.ViewedAt,
+			EncryptedPassword: viewed.EncryptedPassword,
+			ServiceName:       viewed.ServiceName,
+			Username:          viewed.Username,
+			CreatedAt:         viewed.CreatedAt,
+			ViewedAt:          viewed.ViewedAt,
+			Scope:             viewed.Scope,
+			ViewsRemaining:    viewsRemaining(viewed),
 		}
 
-		// Delete immediately after viewing (one-time use)
-		// We could also keep it for status checks but delete the password
-		store.Delete(token)
-
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
-		
-		log.Printf("Share viewed and deleted: token=%s, service=%s", 
-			token[:10]+"...", shareData.ServiceName)
+
+		log.Printf("Share viewed: token=%s, service=%s, scope=%s",
+			token[:10]+"...", viewed.ServiceName, viewed.Scope)
+
+		auditLog.Record(AuditEvent{
+			Event:       AuditShareViewed,
+			Outcome:     OutcomeSuccess,
+			TokenPrefix: tokenPrefix(token),
+			ServiceName: viewed.ServiceName,
+			SourceIP:    clientIP(r),
+			UserAgent:   r.UserAgent(),
+		})
 	}
 }
 
-// DeleteShareHandler handles DELETE /api/share/:token
-func DeleteShareHandler(store *ShareStore) http.HandlerFunc {
+// viewsRemaining reports how many more views a multi_view share has left,
+// or nil for scopes that don't track a view budget.
+func viewsRemaining(data *ShareData) *int {
+	if data.Scope != ScopeMultiView || data.MaxViews <= 0 {
+		return nil
+	}
+	remaining := data.MaxViews - data.ViewCount
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// SubmitShareReplyHandler handles POST /api/share/:token/reply. The
+// recipient posts an encrypted reply using the original share token; the
+// creator later fetches it via FetchShareReplyHandler using the separate
+// ReplyToken returned at creation time.
+func SubmitShareReplyHandler(store ShareStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := chi.URLParam(r, "token")
+		if token == "" {
+			http.Error(w, "Token required", http.StatusBadRequest)
+			return
+		}
+		submitShareReply(store, w, r, token)
+	}
+}
+
+// FetchShareReplyHandler handles GET /api/share/:replyToken/reply.
+func FetchShareReplyHandler(store ShareStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		replyToken := chi.URLParam(r, "token")
+		if replyToken == "" {
+			http.Error(w, "Token required", http.StatusBadRequest)
 			return
 		}
+		fetchShareReply(store, w, replyToken)
+	}
+}
+
+// submitShareReply stores the recipient's encrypted reply against a
+// write-scope share, identified by its original share token.
+func submitShareReply(store ShareStore, w http.ResponseWriter, r *http.Request, token string) {
+	var req ReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.EncryptedReply == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	shareData, exists, err := store.Get(token)
+	if err != nil {
+		log.Printf("Error retrieving share: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Share not found or expired", http.StatusNotFound)
+		return
+	}
+
+	if time.Now().After(shareData.ExpiresAt) {
+		if err := store.Delete(token); err != nil {
+			log.Printf("Error deleting expired share: %v", err)
+		}
+		http.Error(w, "Share has expired", http.StatusGone)
+		return
+	}
 
-		// Extract token from path
-		path := strings.TrimPrefix(r.URL.Path, "/api/share/")
-		token := path
+	if shareData.Scope != ScopeWrite {
+		http.Error(w, "This share does not accept replies", http.StatusBadRequest)
+		return
+	}
+
+	if err := store.SetReply(token, req.EncryptedReply); err != nil {
+		log.Printf("Error storing reply: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reply submitted"})
+
+	log.Printf("Reply submitted: token=%s, service=%s", token[:10]+"...", shareData.ServiceName)
+}
+
+// fetchShareReply returns the recipient's reply to the share creator,
+// identified by the share's paired ReplyToken.
+func fetchShareReply(store ShareStore, w http.ResponseWriter, replyToken string) {
+	shareData, err := store.GetByReplyToken(replyToken)
+	if err != nil {
+		log.Printf("Error retrieving reply: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if shareData == nil || shareData.EncryptedReply == "" {
+		http.Error(w, "No reply yet", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReplyResponse{
+		EncryptedReply: shareData.EncryptedReply,
+		RepliedAt:      shareData.RepliedAt,
+	})
+}
+
+// UnlockShareHandler handles POST /api/share/:token/unlock. On a correct
+// password it issues a short-lived credential that GetShareHandler accepts
+// via the unlock_token query parameter, so the SPA never has to buffer the
+// encrypted payload alongside the password prompt.
+func UnlockShareHandler(store ShareStore, guard *UnlockGuard, auditLog *AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := chi.URLParam(r, "token")
+		if token == "" {
+			http.Error(w, "Token required", http.StatusBadRequest)
+			return
+		}
+
+		if !guard.Allow(token) {
+			http.Error(w, "Too many unlock attempts, try again later", http.StatusTooManyRequests)
+			return
+		}
+
+		var req UnlockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		shareData, exists, err := store.Get(token)
+		if err != nil {
+			log.Printf("Error retrieving share: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Share not found or expired", http.StatusNotFound)
+			return
+		}
+
+		if time.Now().After(shareData.ExpiresAt) {
+			if err := store.Delete(token); err != nil {
+				log.Printf("Error deleting expired share: %v", err)
+			}
+			http.Error(w, "Share has expired", http.StatusGone)
+			return
+		}
+
+		if !shareData.HasPassword() {
+			http.Error(w, "This share is not password protected", http.StatusBadRequest)
+			return
+		}
+
+		// Gate the password check itself behind the same CIDR/recipient
+		// restrictions GetShareHandler enforces before releasing the
+		// payload, so a disallowed caller can't use this endpoint to
+		// brute-force the password from outside the allow-list.
+		if _, ok := authorizeShareRequest(w, r, token, shareData, auditLog); !ok {
+			return
+		}
+
+		if !verifySharePassword(req.Password, shareData.PasswordSalt, shareData.PasswordHash) {
+			auditLog.Record(AuditEvent{
+				Event:       AuditUnlockFailed,
+				Outcome:     OutcomeFailure,
+				TokenPrefix: tokenPrefix(token),
+				ServiceName: shareData.ServiceName,
+				SourceIP:    clientIP(r),
+				UserAgent:   r.UserAgent(),
+				Detail:      "invalid password",
+			})
+			http.Error(w, "Invalid password", http.StatusUnauthorized)
+			return
+		}
+
+		credential, expiresAt, err := guard.Issue(token)
+		if err != nil {
+			log.Printf("Error issuing unlock credential: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(UnlockResponse{
+			UnlockToken: credential,
+			ExpiresAt:   expiresAt,
+		})
+
+		log.Printf("Share unlocked: token=%s, service=%s", token[:10]+"...", shareData.ServiceName)
+	}
+}
+
+// DeleteShareHandler handles DELETE /api/share/:token
+func DeleteShareHandler(store ShareStore, auditLog *AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := chi.URLParam(r, "token")
 		if token == "" {
 			http.Error(w, "Token required", http.StatusBadRequest)
 			return
 		}
 
 		// Check if exists
-		_, exists := store.Get(token)
+		shareData, exists, err := store.Get(token)
+		if err != nil {
+			log.Printf("Error retrieving share: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 		if !exists {
 			http.Error(w, "Share not found", http.StatusNotFound)
 			return
 		}
 
 		// Delete the share
-		store.Delete(token)
+		if err := store.Delete(token); err != nil {
+			log.Printf("Error deleting share: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "Share deleted successfully",
 		})
-		
+
 		log.Printf("Share manually deleted: token=%s", token[:10]+"...")
+
+		auditLog.Record(AuditEvent{
+			Event:       AuditShareDeleted,
+			Outcome:     OutcomeSuccess,
+			TokenPrefix: tokenPrefix(token),
+			ServiceName: shareData.ServiceName,
+			SourceIP:    clientIP(r),
+			UserAgent:   r.UserAgent(),
+		})
 	}
 }
 
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestRouter wires up the share-lifecycle routes against a fresh
+// MemoryStore/UnlockGuard/AuditLog, mirroring the subset of newRouter this
+// file's tests exercise.
+func newTestRouter(t *testing.T) (chi.Router, *AuditLog) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	guard := NewUnlockGuard()
+	auditLog, err := NewAuditLog(t.TempDir() + "/audit.log")
+	if err != nil {
+		t.Fatalf("NewAuditLog: %v", err)
+	}
+
+	router := chi.NewRouter()
+	router.Post("/api/share", CreateShareHandler(store, "http://localhost:8080", auditLog))
+	router.Get("/api/share/{token}", GetShareHandler(store, guard, auditLog))
+	router.Delete("/api/share/{token}", DeleteShareHandler(store, auditLog))
+	router.Post("/api/share/{token}/unlock", UnlockShareHandler(store, guard, auditLog))
+	return router, auditLog
+}
+
+func doJSON(t *testing.T, router chi.Router, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func createShare(t *testing.T, router chi.Router, req ShareRequest) ShareResponse {
+	t.Helper()
+
+	rec := doJSON(t, router, http.MethodPost, "/api/share", req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/share: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ShareResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding ShareResponse: %v", err)
+	}
+	return resp
+}
+
+func TestShareLifecycle_CreateGetDelete(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	share := createShare(t, router, ShareRequest{
+		EncryptedPassword: "ciphertext",
+		ServiceName:       "example-service",
+		Username:          "alice",
+	})
+	if share.Token == "" {
+		t.Fatal("CreateShareHandler returned an empty token")
+	}
+
+	get := doJSON(t, router, http.MethodGet, "/api/share/"+share.Token, nil)
+	if get.Code != http.StatusOK {
+		t.Fatalf("GET /api/share/:token: status %d, body %s", get.Code, get.Body.String())
+	}
+	var retrieved ShareRetrieveResponse
+	if err := json.Unmarshal(get.Body.Bytes(), &retrieved); err != nil {
+		t.Fatalf("decoding ShareRetrieveResponse: %v", err)
+	}
+	if retrieved.EncryptedPassword != "ciphertext" {
+		t.Fatalf("EncryptedPassword = %q, want %q", retrieved.EncryptedPassword, "ciphertext")
+	}
+
+	// one_time scope: the share is deleted on its first view, so a second
+	// GET must not return the payload again.
+	again := doJSON(t, router, http.MethodGet, "/api/share/"+share.Token, nil)
+	if again.Code != http.StatusNotFound {
+		t.Fatalf("second GET: status %d, want %d", again.Code, http.StatusNotFound)
+	}
+}
+
+func TestShareLifecycle_Delete(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	share := createShare(t, router, ShareRequest{
+		EncryptedPassword: "ciphertext",
+		ServiceName:       "example-service",
+		Username:          "alice",
+	})
+
+	del := doJSON(t, router, http.MethodDelete, "/api/share/"+share.Token, nil)
+	if del.Code != http.StatusOK {
+		t.Fatalf("DELETE /api/share/:token: status %d, body %s", del.Code, del.Body.String())
+	}
+
+	get := doJSON(t, router, http.MethodGet, "/api/share/"+share.Token, nil)
+	if get.Code != http.StatusNotFound {
+		t.Fatalf("GET after delete: status %d, want %d", get.Code, http.StatusNotFound)
+	}
+}
+
+func TestShareUnlock_WrongThenRightPassword(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	share := createShare(t, router, ShareRequest{
+		EncryptedPassword: "ciphertext",
+		ServiceName:       "example-service",
+		Username:          "alice",
+		Password:          "correct horse battery staple",
+	})
+
+	// Without an unlock_token, the payload is withheld.
+	locked := doJSON(t, router, http.MethodGet, "/api/share/"+share.Token, nil)
+	if locked.Code != http.StatusUnauthorized {
+		t.Fatalf("GET without unlock: status %d, want %d", locked.Code, http.StatusUnauthorized)
+	}
+
+	wrong := doJSON(t, router, http.MethodPost, "/api/share/"+share.Token+"/unlock", UnlockRequest{Password: "nope"})
+	if wrong.Code != http.StatusUnauthorized {
+		t.Fatalf("unlock with wrong password: status %d, want %d", wrong.Code, http.StatusUnauthorized)
+	}
+
+	right := doJSON(t, router, http.MethodPost, "/api/share/"+share.Token+"/unlock", UnlockRequest{Password: "correct horse battery staple"})
+	if right.Code != http.StatusOK {
+		t.Fatalf("unlock with correct password: status %d, body %s", right.Code, right.Body.String())
+	}
+	var unlock UnlockResponse
+	if err := json.Unmarshal(right.Body.Bytes(), &unlock); err != nil {
+		t.Fatalf("decoding UnlockResponse: %v", err)
+	}
+	if unlock.UnlockToken == "" {
+		t.Fatal("UnlockShareHandler returned an empty unlock_token")
+	}
+
+	get := doJSON(t, router, http.MethodGet, "/api/share/"+share.Token+"?unlock_token="+unlock.UnlockToken, nil)
+	if get.Code != http.StatusOK {
+		t.Fatalf("GET with unlock_token: status %d, body %s", get.Code, get.Body.String())
+	}
+}
+
+func TestShareUnlock_RateLimited(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	share := createShare(t, router, ShareRequest{
+		EncryptedPassword: "ciphertext",
+		ServiceName:       "example-service",
+		Username:          "alice",
+		Password:          "swordfish",
+	})
+
+	for i := 0; i < maxUnlockAttempts; i++ {
+		rec := doJSON(t, router, http.MethodPost, "/api/share/"+share.Token+"/unlock", UnlockRequest{Password: "wrong"})
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status %d, want %d", i+1, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	limited := doJSON(t, router, http.MethodPost, "/api/share/"+share.Token+"/unlock", UnlockRequest{Password: "swordfish"})
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt after limit: status %d, want %d (even with the correct password)", limited.Code, http.StatusTooManyRequests)
+	}
+}
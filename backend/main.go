@@ -1,16 +1,22 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
-// CORS middleware
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Get allowed origin from environment or allow all
+// corsMiddleware sets the CORS headers every API response needs and
+// short-circuits preflight requests. Registered once via router.Use, so it
+// runs ahead of routing for every request, including ones that don't match
+// any route.
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		allowedOrigin := os.Getenv("ALLOWED_ORIGIN")
 		if allowedOrigin == "" {
 			allowedOrigin = "*"
@@ -18,38 +24,116 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Handle preflight requests
-		if r.Method == "OPTIONS" {
+		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		next(w, r)
-	}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // cleanupWorker runs periodically to clean up expired shares
-func cleanupWorker(store *ShareStore, interval time.Duration) {
+func cleanupWorker(store ShareStore, interval time.Duration, auditLog *AuditLog) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		count := store.CleanupExpired()
+		count, err := store.CleanupExpired()
+		if err != nil {
+			log.Printf("Error cleaning up expired shares: %v", err)
+			continue
+		}
 		if count > 0 {
 			log.Printf("Cleaned up %d expired shares", count)
+			auditLog.Record(AuditEvent{
+				Event:   AuditCleanup,
+				Outcome: OutcomeSuccess,
+				Detail:  fmt.Sprintf("%d shares removed", count),
+			})
+		}
+	}
+}
+
+// newRouter builds the API + static-file routing tree.
+func newRouter(store ShareStore, guard *UnlockGuard, auditLog *AuditLog, baseURL, adminToken, frontendPath string) http.Handler {
+	router := chi.NewRouter()
+	router.Use(corsMiddleware)
+
+	router.Get("/api/health", HealthHandler)
+	router.Post("/api/share", CreateShareHandler(store, baseURL, auditLog))
+	router.Get("/api/share/{token}", GetShareHandler(store, guard, auditLog))
+	router.Delete("/api/share/{token}", DeleteShareHandler(store, auditLog))
+	router.Post("/api/share/{token}/unlock", UnlockShareHandler(store, guard, auditLog))
+	router.Post("/api/share/{token}/reply", SubmitShareReplyHandler(store))
+	router.Get("/api/share/{token}/reply", FetchShareReplyHandler(store))
+
+	router.Route("/api/admin", func(admin chi.Router) {
+		admin.Use(adminAuthMiddleware(adminToken))
+		admin.Get("/shares", AdminSharesHandler(store))
+		admin.Get("/audit", AdminAuditHandler(auditLog))
+	})
+
+	router.Get("/*", spaHandler(frontendPath))
+
+	return router
+}
+
+// spaHandler serves static files out of frontendPath, falling back to
+// index.html for client-side (SPA) routes. Any /api/* path reaching this
+// handler didn't match a registered API route above, and is a 404 rather
+// than an SPA route.
+func spaHandler(frontendPath string) http.HandlerFunc {
+	fs := http.FileServer(http.Dir(frontendPath))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api") {
+			http.NotFound(w, r)
+			return
+		}
+
+		// For /share/* routes, serve index.html (SPA routing)
+		if strings.HasPrefix(r.URL.Path, "/share") {
+			http.ServeFile(w, r, frontendPath+"/index.html")
+			return
+		}
+
+		// Check if file exists
+		path := frontendPath + r.URL.Path
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// File doesn't exist, serve index.html for client-side routing
+			http.ServeFile(w, r, frontendPath+"/index.html")
+			return
 		}
+
+		fs.ServeHTTP(w, r)
 	}
 }
 
 func main() {
-	// Initialize share store
-	store := NewShareStore()
+	// Initialize share store (backend selected via STORE_BACKEND)
+	store, err := NewStore()
+	if err != nil {
+		log.Fatalf("Error initializing share store: %v", err)
+	}
+
+	// Tracks unlock rate limiting and short-lived retrieval credentials for
+	// password-protected shares
+	guard := NewUnlockGuard()
+
+	// Append-only audit trail of share lifecycle events, queried via
+	// GET /api/admin/audit
+	auditLog, err := NewAuditLog(envOrDefault("AUDIT_LOG_PATH", "audit.log"))
+	if err != nil {
+		log.Fatalf("Error initializing audit log: %v", err)
+	}
 
 	// Start cleanup worker (runs every 10 minutes)
-	go cleanupWorker(store, 10*time.Minute)
+	go cleanupWorker(store, 10*time.Minute, auditLog)
 
 	// Get configuration from environment or use defaults
 	port := os.Getenv("PORT")
@@ -62,19 +146,9 @@ func main() {
 		baseURL = "http://localhost:8080"
 	}
 
-	// Setup API routes
-	http.HandleFunc("/api/health", corsMiddleware(HealthHandler))
-	http.HandleFunc("/api/share", corsMiddleware(CreateShareHandler(store, baseURL)))
-	http.HandleFunc("/api/share/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			GetShareHandler(store)(w, r)
-		case http.MethodDelete:
-			DeleteShareHandler(store)(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
+	// Admin API, protected by a bearer token from ADMIN_TOKEN. Left
+	// unconfigured (and therefore disabled) unless the operator sets it.
+	adminToken := os.Getenv("ADMIN_TOKEN")
 
 	// Serve static files from frontend directory
 	// Try both relative paths (for local) and absolute (for production)
@@ -86,41 +160,14 @@ func main() {
 		frontendPath = "/app/frontend"
 	}
 
-	// Create file server
-	fs := http.FileServer(http.Dir(frontendPath))
-
-	// Handle all routes - serve index.html for SPA routing
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// If it's an API request, it should have been handled above
-		if len(r.URL.Path) >= 4 && r.URL.Path[:4] == "/api" {
-			http.NotFound(w, r)
-			return
-		}
-
-		// For /share/* routes, serve index.html (SPA routing)
-		if len(r.URL.Path) >= 6 && r.URL.Path[:6] == "/share" {
-			http.ServeFile(w, r, frontendPath+"/index.html")
-			return
-		}
-
-		// Check if file exists
-		path := frontendPath + r.URL.Path
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			// File doesn't exist, serve index.html for client-side routing
-			http.ServeFile(w, r, frontendPath+"/index.html")
-			return
-		}
-
-		// Serve the requested file
-		fs.ServeHTTP(w, r)
-	})
+	router := newRouter(store, guard, auditLog, baseURL, adminToken, frontendPath)
 
 	log.Printf("🔐 Password Manager Server starting on port %s", port)
 	log.Printf("📍 Base URL: %s", baseURL)
 	log.Printf("📁 Frontend path: %s", frontendPath)
 	log.Printf("🔧 API Health: http://localhost:%s/api/health", port)
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	if err := http.ListenAndServe(":"+port, router); err != nil {
 		log.Fatal(err)
 	}
 }
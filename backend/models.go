@@ -1,19 +1,82 @@
 package main
 
 import (
-	"sync"
 	"time"
 )
 
+// Scope controls how many times, and by whom, a share may be retrieved.
+// Modeled on SFTPGo's share scopes.
+type Scope string
+
+const (
+	// ScopeOneTime is consumed and deleted on its first successful view.
+	ScopeOneTime Scope = "one_time"
+	// ScopeMultiView may be viewed up to MaxViews times until expiration.
+	ScopeMultiView Scope = "multi_view"
+	// ScopeWrite lets the recipient view the payload once and post back an
+	// encrypted reply that the creator retrieves via ShareData.ReplyToken.
+	ScopeWrite Scope = "write"
+)
+
+// IsValid reports whether s is one of the recognized share scopes.
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeOneTime, ScopeMultiView, ScopeWrite:
+		return true
+	default:
+		return false
+	}
+}
+
 // ShareData represents a password share with expiration and view tracking
 type ShareData struct {
-	EncryptedPassword string    `json:"encrypted_password"`
-	ServiceName       string    `json:"service_name"`
-	Username          string    `json:"username"`
-	CreatedAt         time.Time `json:"created_at"`
-	ExpiresAt         time.Time `json:"expires_at"`
-	Viewed            bool      `json:"viewed"`
+	EncryptedPassword string     `json:"encrypted_password"`
+	ServiceName       string     `json:"service_name"`
+	Username          string     `json:"username"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	Viewed            bool       `json:"viewed"`
 	ViewedAt          *time.Time `json:"viewed_at,omitempty"`
+
+	// Scope controls how the share may be retrieved. MaxViews and
+	// ViewCount only apply to ScopeMultiView; ViewCount is still tracked
+	// for the other scopes for observability.
+	Scope     Scope `json:"scope"`
+	MaxViews  int   `json:"max_views,omitempty"`
+	ViewCount int   `json:"view_count"`
+
+	// ReplyToken/EncryptedReply/RepliedAt back the write scope: the
+	// recipient posts an encrypted reply using the original share token,
+	// and the creator fetches it using the separate ReplyToken returned at
+	// creation time, so the share token alone can't be used to read it back.
+	ReplyToken     string     `json:"reply_token,omitempty"`
+	EncryptedReply string     `json:"encrypted_reply,omitempty"`
+	RepliedAt      *time.Time `json:"replied_at,omitempty"`
+
+	// PasswordHash/PasswordSalt are set when the share was created with an
+	// optional unlock password. The plaintext password is never stored.
+	// These are kept out of every API response by building explicit
+	// response structs in handlers.go rather than marshaling ShareData
+	// directly; the real json tags (rather than "-") let the store backends
+	// round-trip them for persistence and migration.
+	PasswordHash string `json:"password_hash,omitempty"`
+	PasswordSalt string `json:"password_salt,omitempty"`
+
+	// AllowedRecipients/AllowedCIDRs restrict who may retrieve the share.
+	// When AllowedRecipients is set, GetShareHandler requires a valid
+	// per-recipient claim signature (see signClaim) instead of accepting
+	// the bare token; when AllowedCIDRs is set, the requester's address
+	// (see clientIP) must fall within one of them. ViewedBy records which
+	// recipients have already claimed the share.
+	AllowedRecipients []string             `json:"allowed_recipients,omitempty"`
+	AllowedCIDRs      []string             `json:"allowed_cidrs,omitempty"`
+	ViewedBy          map[string]time.Time `json:"viewed_by,omitempty"`
+}
+
+// HasPassword reports whether retrieving this share requires unlocking
+// with a password first.
+func (d *ShareData) HasPassword() bool {
+	return d.PasswordHash != ""
 }
 
 // ShareRequest represents the incoming request to create a share
@@ -21,98 +84,70 @@ type ShareRequest struct {
 	EncryptedPassword string `json:"encrypted_password" binding:"required"`
 	ServiceName       string `json:"service_name" binding:"required"`
 	Username          string `json:"username" binding:"required"`
-	ExpirationHours   int    `json:"expiration_hours"` // default 24 if not set
-}
-
-// ShareResponse represents the response when creating a share
-type ShareResponse struct {
-	Token     string    `json:"token"`
-	ShareURL  string    `json:"share_url"`
-	ExpiresAt time.Time `json:"expires_at"`
-}
+	ExpirationHours   int    `json:"expiration_hours"`    // default 24 if not set
+	Password          string `json:"password,omitempty"`  // optional unlock password
+	Scope             Scope  `json:"scope,omitempty"`     // default one_time
+	MaxViews          int    `json:"max_views,omitempty"` // required for multi_view scope
 
-// ShareRetrieveResponse represents the response when retrieving a share
-type ShareRetrieveResponse struct {
-	EncryptedPassword string     `json:"encrypted_password"`
-	ServiceName       string     `json:"service_name"`
-	Username          string     `json:"username"`
-	CreatedAt         time.Time  `json:"created_at"`
-	ViewedAt          *time.Time `json:"viewed_at,omitempty"`
+	// AllowedRecipients restricts retrieval to these email addresses, each
+	// issued its own signed claim link instead of one shareable URL.
+	AllowedRecipients []string `json:"allowed_recipients,omitempty"`
+	// AllowedCIDRs restricts retrieval to requests whose client address
+	// falls within one of these ranges.
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
 }
 
-// ShareStore manages the in-memory storage of password shares
-type ShareStore struct {
-	shares map[string]*ShareData
-	mu     sync.RWMutex
+// UnlockRequest represents the body of POST /api/share/:token/unlock
+type UnlockRequest struct {
+	Password string `json:"password" binding:"required"`
 }
 
-// NewShareStore creates a new share store
-func NewShareStore() *ShareStore {
-	return &ShareStore{
-		shares: make(map[string]*ShareData),
-	}
+// UnlockResponse is returned once the unlock password is verified. The
+// SPA exchanges UnlockToken for the encrypted payload via GetShareHandler
+// without buffering the password itself.
+type UnlockResponse struct {
+	UnlockToken string    `json:"unlock_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
 
-// Add stores a new share
-func (s *ShareStore) Add(token string, data *ShareData) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.shares[token] = data
+// ReplyRequest represents the body of POST /api/share/:token/reply
+type ReplyRequest struct {
+	EncryptedReply string `json:"encrypted_reply" binding:"required"`
 }
 
-// Get retrieves a share by token
-func (s *ShareStore) Get(token string) (*ShareData, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	data, exists := s.shares[token]
-	return data, exists
+// ReplyResponse represents the body of GET /api/share/:replyToken/reply
+type ReplyResponse struct {
+	EncryptedReply string     `json:"encrypted_reply"`
+	RepliedAt      *time.Time `json:"replied_at,omitempty"`
 }
 
-// MarkViewed marks a share as viewed
-func (s *ShareStore) MarkViewed(token string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if data, exists := s.shares[token]; exists {
-		now := time.Now()
-		data.Viewed = true
-		data.ViewedAt = &now
-	}
-}
+// ShareResponse represents the response when creating a share
+type ShareResponse struct {
+	Token      string    `json:"token"`
+	ShareURL   string    `json:"share_url"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ReplyToken string    `json:"reply_token,omitempty"` // set for write-scope shares
 
-// Delete removes a share
-func (s *ShareStore) Delete(token string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.shares, token)
+	// ClaimLinks is set instead of a single usable ShareURL when the share
+	// has AllowedRecipients: each recipient gets their own signed link, so
+	// a leaked link can't be claimed by anyone else.
+	ClaimLinks []ClaimLink `json:"claim_links,omitempty"`
 }
 
-// CleanupExpired removes expired shares
-func (s *ShareStore) CleanupExpired() int {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	count := 0
-	now := time.Now()
-	
-	for token, data := range s.shares {
-		if now.After(data.ExpiresAt) {
-			delete(s.shares, token)
-			count++
-		}
-	}
-	
-	return count
+// ClaimLink is a recipient's individually-signed retrieval link for a
+// share created with AllowedRecipients.
+type ClaimLink struct {
+	Email string `json:"email"`
+	URL   string `json:"url"`
 }
 
-// GetAll returns all shares (for debugging/admin purposes)
-func (s *ShareStore) GetAll() map[string]*ShareData {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
-	// Return a copy to prevent external modifications
-	copy := make(map[string]*ShareData)
-	for k, v := range s.shares {
-		copy[k] = v
-	}
-	return copy
+// ShareRetrieveResponse represents the response when retrieving a share
+type ShareRetrieveResponse struct {
+	EncryptedPassword string     `json:"encrypted_password"`
+	ServiceName       string     `json:"service_name"`
+	Username          string     `json:"username"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ViewedAt          *time.Time `json:"viewed_at,omitempty"`
+	Scope             Scope      `json:"scope"`
+	ViewsRemaining    *int       `json:"views_remaining,omitempty"` // multi_view scope only
 }
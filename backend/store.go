@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Sentinel errors returned by ShareStore implementations.
+var (
+	ErrShareNotFound = errors.New("share not found")
+	ErrAlreadyViewed = errors.New("share already viewed")
+)
+
+// ShareStore abstracts the persistence backend for password shares, so a
+// single-instance deployment can run entirely in memory, keep shares across
+// restarts in a local BoltDB or SQLite file, or, when horizontally scaled,
+// share state between instances through Redis.
+type ShareStore interface {
+	// Add stores a new share.
+	Add(token string, data *ShareData) error
+	// Get retrieves a share by token.
+	Get(token string) (*ShareData, bool, error)
+	// MarkViewed marks a share as viewed without deleting it.
+	MarkViewed(token string) error
+	// Delete removes a share.
+	Delete(token string) error
+	// CleanupExpired removes expired shares and returns how many were removed.
+	CleanupExpired() (int, error)
+	// GetAll returns every share currently held by the store.
+	GetAll() (map[string]*ShareData, error)
+
+	// RecordView atomically registers a view against token according to
+	// its scope: one_time and write shares are marked viewed on their
+	// first view (one_time is also deleted), and multi_view shares have
+	// ViewCount incremented and are deleted once it reaches MaxViews. It
+	// returns ErrAlreadyViewed (with the existing record) for a write share
+	// that has already been viewed, and ErrShareNotFound for unknown tokens,
+	// which includes one_time and multi_view shares deleted once their view
+	// allowance is used up, so a share is never delivered more times than
+	// its scope allows under concurrent GETs. viewer, when non-empty, is recorded
+	// in ShareData.ViewedBy (keyed by recipient email) as part of the
+	// same atomic operation.
+	RecordView(token, viewer string) (*ShareData, error)
+
+	// SetReply stores an encrypted reply against a write-scope share,
+	// looked up by the original share token.
+	SetReply(token, encryptedReply string) error
+
+	// GetByReplyToken looks up a share by its paired ReplyToken (see
+	// ShareData.ReplyToken), for the share creator to retrieve a
+	// recipient's reply without knowing the original share token. It
+	// returns (nil, nil) rather than an error when no share has that
+	// reply token.
+	GetByReplyToken(replyToken string) (*ShareData, error)
+}
+
+// StoreBackendName returns the backend selected by the STORE_BACKEND
+// environment variable, defaulting to "memory".
+func StoreBackendName() string {
+	return envOrDefault("STORE_BACKEND", "memory")
+}
+
+// NewStore builds the ShareStore selected by the STORE_BACKEND environment
+// variable ("memory", "bolt", "sqlite", or "redis"). It defaults to "memory".
+func NewStore() (ShareStore, error) {
+	switch backend := StoreBackendName(); backend {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "bolt":
+		return NewBoltStore(envOrDefault("BOLT_DB_PATH", "shares.db"))
+	case "sqlite":
+		return NewSQLiteStore(envOrDefault("SQLITE_DB_PATH", "shares.sqlite"))
+	case "redis":
+		return NewRedisStore(envOrDefault("REDIS_ADDR", "localhost:6379"))
+	default:
+		return nil, fmt.Errorf("unknown STORE_BACKEND %q", backend)
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// recordViewer records viewer against data.ViewedBy at ts, used by
+// RecordView implementations that share ShareData in memory (MemoryStore,
+// BoltStore, SQLiteStore). It is a no-op when viewer is empty, which is the
+// case for shares without AllowedRecipients.
+func recordViewer(data *ShareData, viewer string, ts time.Time) {
+	if viewer == "" {
+		return
+	}
+	if data.ViewedBy == nil {
+		data.ViewedBy = make(map[string]time.Time)
+	}
+	data.ViewedBy[viewer] = ts
+}
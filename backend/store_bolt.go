@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var sharesBucket = []byte("shares")
+
+// BoltStore persists shares in a local BoltDB file, so they survive process
+// restarts without requiring an external database server.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening bolt store: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sharesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing bolt store: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Add stores a new share.
+func (s *BoltStore) Add(token string, data *ShareData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sharesBucket).Put([]byte(token), encoded)
+	})
+}
+
+// Get retrieves a share by token.
+func (s *BoltStore) Get(token string) (*ShareData, bool, error) {
+	var data *ShareData
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(sharesBucket).Get([]byte(token))
+		if raw == nil {
+			return nil
+		}
+		data = &ShareData{}
+		return json.Unmarshal(raw, data)
+	})
+	return data, data != nil, err
+}
+
+// MarkViewed marks a share as viewed.
+func (s *BoltStore) MarkViewed(token string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sharesBucket)
+		raw := b.Get([]byte(token))
+		if raw == nil {
+			return nil
+		}
+
+		data := &ShareData{}
+		if err := json.Unmarshal(raw, data); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		data.Viewed = true
+		data.ViewedAt = &now
+
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(token), encoded)
+	})
+}
+
+// Delete removes a share.
+func (s *BoltStore) Delete(token string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sharesBucket).Delete([]byte(token))
+	})
+}
+
+// CleanupExpired removes expired shares.
+func (s *BoltStore) CleanupExpired() (int, error) {
+	now := time.Now()
+	var expired [][]byte
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sharesBucket)
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			data := &ShareData{}
+			if err := json.Unmarshal(v, data); err != nil {
+				return err
+			}
+			if now.After(data.ExpiresAt) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return len(expired), err
+}
+
+// GetAll returns all shares (for debugging/admin purposes).
+func (s *BoltStore) GetAll() (map[string]*ShareData, error) {
+	all := make(map[string]*ShareData)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sharesBucket).ForEach(func(k, v []byte) error {
+			data := &ShareData{}
+			if err := json.Unmarshal(v, data); err != nil {
+				return err
+			}
+			all[string(k)] = data
+			return nil
+		})
+	})
+	return all, err
+}
+
+// RecordView atomically registers a view according to the share's scope,
+// within a single Bolt transaction, so two concurrent GETs can never both
+// succeed.
+func (s *BoltStore) RecordView(token, viewer string) (*ShareData, error) {
+	var result *ShareData
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sharesBucket)
+		key := []byte(token)
+
+		raw := b.Get(key)
+		if raw == nil {
+			return ErrShareNotFound
+		}
+
+		data := &ShareData{}
+		if err := json.Unmarshal(raw, data); err != nil {
+			return err
+		}
+
+		if data.Scope == ScopeMultiView {
+			if data.MaxViews > 0 && data.ViewCount >= data.MaxViews {
+				result = data
+				return ErrAlreadyViewed
+			}
+
+			now := time.Now()
+			data.ViewCount++
+			data.Viewed = true
+			data.ViewedAt = &now
+			recordViewer(data, viewer, now)
+			result = data
+
+			if data.MaxViews > 0 && data.ViewCount >= data.MaxViews {
+				return b.Delete(key)
+			}
+			return putShare(b, key, data)
+		}
+
+		if data.Viewed {
+			result = data
+			return ErrAlreadyViewed
+		}
+
+		now := time.Now()
+		data.Viewed = true
+		data.ViewedAt = &now
+		data.ViewCount++
+		recordViewer(data, viewer, now)
+		result = data
+
+		if data.Scope == ScopeWrite {
+			return putShare(b, key, data)
+		}
+		return b.Delete(key)
+	})
+
+	return result, err
+}
+
+// SetReply stores an encrypted reply against a write-scope share.
+func (s *BoltStore) SetReply(token, encryptedReply string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sharesBucket)
+		key := []byte(token)
+
+		raw := b.Get(key)
+		if raw == nil {
+			return ErrShareNotFound
+		}
+
+		data := &ShareData{}
+		if err := json.Unmarshal(raw, data); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		data.EncryptedReply = encryptedReply
+		data.RepliedAt = &now
+
+		return putShare(b, key, data)
+	})
+}
+
+// GetByReplyToken looks up a share by its paired ReplyToken.
+func (s *BoltStore) GetByReplyToken(replyToken string) (*ShareData, error) {
+	var result *ShareData
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sharesBucket).ForEach(func(k, v []byte) error {
+			data := &ShareData{}
+			if err := json.Unmarshal(v, data); err != nil {
+				return err
+			}
+			if data.ReplyToken != "" && data.ReplyToken == replyToken {
+				result = data
+			}
+			return nil
+		})
+	})
+
+	return result, err
+}
+
+// putShare marshals and stores data under key within an open transaction.
+func putShare(b *bolt.Bucket, key []byte, data *ShareData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, encoded)
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltStore(t *testing.T) {
+	runShareStoreSuite(t, func() ShareStore {
+		store, err := NewBoltStore(filepath.Join(t.TempDir(), "shares.db"))
+		if err != nil {
+			t.Fatalf("NewBoltStore: %v", err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is the in-memory ShareStore implementation. It is the
+// simplest backend to operate but shares do not survive a process restart.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	shares map[string]*ShareData
+}
+
+// NewMemoryStore creates a new in-memory share store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		shares: make(map[string]*ShareData),
+	}
+}
+
+// Add stores a new share.
+func (s *MemoryStore) Add(token string, data *ShareData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shares[token] = data
+	return nil
+}
+
+// Get retrieves a share by token.
+func (s *MemoryStore) Get(token string) (*ShareData, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, exists := s.shares[token]
+	return data, exists, nil
+}
+
+// MarkViewed marks a share as viewed.
+func (s *MemoryStore) MarkViewed(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data, exists := s.shares[token]; exists {
+		now := time.Now()
+		data.Viewed = true
+		data.ViewedAt = &now
+	}
+	return nil
+}
+
+// Delete removes a share.
+func (s *MemoryStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.shares, token)
+	return nil
+}
+
+// CleanupExpired removes expired shares.
+func (s *MemoryStore) CleanupExpired() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	now := time.Now()
+
+	for token, data := range s.shares {
+		if now.After(data.ExpiresAt) {
+			delete(s.shares, token)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// GetAll returns all shares (for debugging/admin purposes).
+func (s *MemoryStore) GetAll() (map[string]*ShareData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make(map[string]*ShareData, len(s.shares))
+	for k, v := range s.shares {
+		all[k] = v
+	}
+	return all, nil
+}
+
+// RecordView atomically registers a view according to the share's scope,
+// under the same lock, so two concurrent GETs can never both succeed.
+func (s *MemoryStore) RecordView(token, viewer string) (*ShareData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, exists := s.shares[token]
+	if !exists {
+		return nil, ErrShareNotFound
+	}
+
+	if data.Scope == ScopeMultiView {
+		if data.MaxViews > 0 && data.ViewCount >= data.MaxViews {
+			return data, ErrAlreadyViewed
+		}
+
+		now := time.Now()
+		data.ViewCount++
+		data.Viewed = true
+		data.ViewedAt = &now
+		recordViewer(data, viewer, now)
+
+		if data.MaxViews > 0 && data.ViewCount >= data.MaxViews {
+			delete(s.shares, token)
+		}
+		return data, nil
+	}
+
+	if data.Viewed {
+		return data, ErrAlreadyViewed
+	}
+
+	now := time.Now()
+	data.Viewed = true
+	data.ViewedAt = &now
+	data.ViewCount++
+	recordViewer(data, viewer, now)
+
+	// write-scope shares stay around so the recipient can still post a
+	// reply and the creator can fetch it via the paired ReplyToken.
+	if data.Scope != ScopeWrite {
+		delete(s.shares, token)
+	}
+
+	return data, nil
+}
+
+// SetReply stores an encrypted reply against a write-scope share.
+func (s *MemoryStore) SetReply(token, encryptedReply string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, exists := s.shares[token]
+	if !exists {
+		return ErrShareNotFound
+	}
+
+	now := time.Now()
+	data.EncryptedReply = encryptedReply
+	data.RepliedAt = &now
+	return nil
+}
+
+// GetByReplyToken looks up a share by its paired ReplyToken.
+func (s *MemoryStore) GetByReplyToken(replyToken string) (*ShareData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, data := range s.shares {
+		if data.ReplyToken != "" && data.ReplyToken == replyToken {
+			return data, nil
+		}
+	}
+	return nil, nil
+}
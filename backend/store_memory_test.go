@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestMemoryStore(t *testing.T) {
+	runShareStoreSuite(t, func() ShareStore {
+		return NewMemoryStore()
+	})
+}
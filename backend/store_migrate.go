@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DumpStore serializes every share in src to its JSON representation,
+// keyed by token.
+func DumpStore(src ShareStore) ([]byte, error) {
+	all, err := src.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("dumping store: %w", err)
+	}
+	return json.MarshalIndent(all, "", "  ")
+}
+
+// LoadStore loads the JSON representation produced by DumpStore into dst,
+// preserving tokens, and returns how many shares were migrated.
+func LoadStore(dst ShareStore, data []byte) (int, error) {
+	var shares map[string]*ShareData
+	if err := json.Unmarshal(data, &shares); err != nil {
+		return 0, fmt.Errorf("loading store: %w", err)
+	}
+
+	count := 0
+	for token, share := range shares {
+		if err := dst.Add(token, share); err != nil {
+			return count, fmt.Errorf("loading store: adding token %s...: %w", truncate(token, 10), err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// MigrateStore copies every share from src to dst via their JSON
+// representation, e.g. to move shares from the in-memory backend to a
+// durable one without downtime.
+func MigrateStore(src, dst ShareStore) (int, error) {
+	data, err := DumpStore(src)
+	if err != nil {
+		return 0, err
+	}
+	return LoadStore(dst, data)
+}
+
+// truncate shortens s to at most n characters, for error messages that
+// must not include a full token.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recordViewScript atomically registers a view against a share according
+// to its scope, so concurrent GETs against different API instances can
+// never deliver it more times than its scope allows.
+var recordViewScript = redis.NewScript(`
+local raw = redis.call("GET", KEYS[1])
+if raw == false then
+	return false
+end
+
+local data = cjson.decode(raw)
+local viewer = ARGV[2]
+
+if data.scope == "multi_view" then
+	local maxViews = tonumber(data.max_views) or 0
+	local viewCount = tonumber(data.view_count) or 0
+	if maxViews > 0 and viewCount >= maxViews then
+		return {raw, "1"}
+	end
+
+	data.view_count = viewCount + 1
+	data.viewed = true
+	data.viewed_at = ARGV[1]
+	if viewer ~= "" then
+		data.viewed_by = data.viewed_by or {}
+		data.viewed_by[viewer] = ARGV[1]
+	end
+	local encoded = cjson.encode(data)
+
+	if maxViews > 0 and data.view_count >= maxViews then
+		redis.call("DEL", KEYS[1])
+	else
+		redis.call("SET", KEYS[1], encoded, "KEEPTTL")
+	end
+	return {encoded, "0"}
+end
+
+if data.viewed then
+	return {raw, "1"}
+end
+
+data.viewed = true
+data.viewed_at = ARGV[1]
+data.view_count = (tonumber(data.view_count) or 0) + 1
+if viewer ~= "" then
+	data.viewed_by = data.viewed_by or {}
+	data.viewed_by[viewer] = ARGV[1]
+end
+local encoded = cjson.encode(data)
+
+if data.scope == "write" then
+	redis.call("SET", KEYS[1], encoded, "KEEPTTL")
+else
+	redis.call("DEL", KEYS[1])
+end
+
+return {encoded, "0"}
+`)
+
+// RedisStore persists shares in Redis, which lets multiple API instances
+// behind a load balancer share state.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the Redis instance at addr.
+func NewRedisStore(addr string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+
+	return &RedisStore{client: client}, nil
+}
+
+func shareKey(token string) string {
+	return "share:" + token
+}
+
+func replyIndexKey(replyToken string) string {
+	return "share-reply:" + replyToken
+}
+
+// Add stores a new share, expiring it from Redis automatically at
+// data.ExpiresAt. Write-scope shares also get a reply-token index entry
+// so GetByReplyToken can find them without a full scan.
+func (s *RedisStore) Add(token string, data *ShareData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(data.ExpiresAt)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+
+	ctx := context.Background()
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, shareKey(token), encoded, ttl)
+	if data.ReplyToken != "" {
+		pipe.Set(ctx, replyIndexKey(data.ReplyToken), token, ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Get retrieves a share by token.
+func (s *RedisStore) Get(token string) (*ShareData, bool, error) {
+	raw, err := s.client.Get(context.Background(), shareKey(token)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	data := &ShareData{}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// MarkViewed marks a share as viewed.
+func (s *RedisStore) MarkViewed(token string) error {
+	data, exists, err := s.Get(token)
+	if err != nil || !exists {
+		return err
+	}
+
+	now := time.Now()
+	data.Viewed = true
+	data.ViewedAt = &now
+	return s.client.Set(context.Background(), shareKey(token), mustMarshal(data), redis.KeepTTL).Err()
+}
+
+// Delete removes a share, along with its reply-token index entry if any.
+func (s *RedisStore) Delete(token string) error {
+	ctx := context.Background()
+	if data, exists, err := s.Get(token); err == nil && exists && data.ReplyToken != "" {
+		s.client.Del(ctx, replyIndexKey(data.ReplyToken))
+	}
+	return s.client.Del(ctx, shareKey(token)).Err()
+}
+
+// CleanupExpired is a no-op: Redis expires keys on its own via the TTL set
+// in Add, so there is nothing left for the periodic cleanup worker to do.
+func (s *RedisStore) CleanupExpired() (int, error) {
+	return 0, nil
+}
+
+// GetAll returns all shares (for debugging/admin purposes).
+func (s *RedisStore) GetAll() (map[string]*ShareData, error) {
+	ctx := context.Background()
+	all := make(map[string]*ShareData)
+
+	iter := s.client.Scan(ctx, 0, "share:*", 0).Iterator()
+	for iter.Next(ctx) {
+		raw, err := s.client.Get(ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data := &ShareData{}
+		if err := json.Unmarshal(raw, data); err != nil {
+			return nil, err
+		}
+		all[strings.TrimPrefix(iter.Val(), "share:")] = data
+	}
+	return all, iter.Err()
+}
+
+// RecordView atomically registers a view according to the share's scope,
+// via recordViewScript.
+func (s *RedisStore) RecordView(token, viewer string) (*ShareData, error) {
+	res, err := recordViewScript.Run(
+		context.Background(), s.client, []string{shareKey(token)}, time.Now().Format(time.RFC3339Nano), viewer,
+	).Result()
+	if err == redis.Nil {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parts, ok := res.([]interface{})
+	if !ok || len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected response from record-view script")
+	}
+
+	raw, _ := parts[0].(string)
+	data := &ShareData{}
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		return nil, err
+	}
+
+	if alreadyViewed, _ := parts[1].(string); alreadyViewed == "1" {
+		return data, ErrAlreadyViewed
+	}
+	return data, nil
+}
+
+// SetReply stores an encrypted reply against a write-scope share.
+func (s *RedisStore) SetReply(token, encryptedReply string) error {
+	data, exists, err := s.Get(token)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrShareNotFound
+	}
+
+	now := time.Now()
+	data.EncryptedReply = encryptedReply
+	data.RepliedAt = &now
+	return s.client.Set(context.Background(), shareKey(token), mustMarshal(data), redis.KeepTTL).Err()
+}
+
+// GetByReplyToken looks up a share by its paired ReplyToken.
+func (s *RedisStore) GetByReplyToken(replyToken string) (*ShareData, error) {
+	token, err := s.client.Get(context.Background(), replyIndexKey(replyToken)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, exists, err := s.Get(token)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return data, nil
+}
+
+// mustMarshal marshals a ShareData we just built ourselves, so a failure
+// would indicate a programming error rather than bad input.
+func mustMarshal(data *ShareData) []byte {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Sprintf("marshaling ShareData: %v", err))
+	}
+	return encoded
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestRedisStore runs the shared suite against a real Redis instance at
+// REDIS_ADDR (default localhost:6379), skipping if one isn't reachable —
+// there's no embedded Redis to fall back to the way Bolt/SQLite use a temp
+// file. The suite reuses the same handful of token names across subtests,
+// so the database is flushed first to keep reruns deterministic.
+func TestRedisStore(t *testing.T) {
+	addr := envOrDefault("REDIS_ADDR", "localhost:6379")
+	probe, err := NewRedisStore(addr)
+	if err != nil {
+		t.Skipf("no redis reachable at %s: %v", addr, err)
+	}
+	if err := probe.client.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("flushing redis test database: %v", err)
+	}
+
+	runShareStoreSuite(t, func() ShareStore {
+		store, err := NewRedisStore(addr)
+		if err != nil {
+			t.Fatalf("NewRedisStore: %v", err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore persists shares in a local SQLite database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite store: %w", err)
+	}
+
+	// SQLite allows only one writer at a time; serialize access through a
+	// single connection rather than fight it with connection pooling. This
+	// also makes RecordView's transaction safe against concurrent GETs
+	// within this process.
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS shares (
+		token       TEXT PRIMARY KEY,
+		data        TEXT NOT NULL,
+		expires_at  DATETIME NOT NULL,
+		reply_token TEXT
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing sqlite store: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_shares_reply_token ON shares(reply_token)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing sqlite store: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Add stores a new share.
+func (s *SQLiteStore) Add(token string, data *ShareData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO shares (token, data, expires_at, reply_token) VALUES (?, ?, ?, ?)`,
+		token, string(encoded), data.ExpiresAt, nullableString(data.ReplyToken))
+	return err
+}
+
+// Get retrieves a share by token.
+func (s *SQLiteStore) Get(token string) (*ShareData, bool, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT data FROM shares WHERE token = ?`, token).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	data := &ShareData{}
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// MarkViewed marks a share as viewed.
+func (s *SQLiteStore) MarkViewed(token string) error {
+	data, exists, err := s.Get(token)
+	if err != nil || !exists {
+		return err
+	}
+
+	now := time.Now()
+	data.Viewed = true
+	data.ViewedAt = &now
+	return s.Add(token, data)
+}
+
+// Delete removes a share.
+func (s *SQLiteStore) Delete(token string) error {
+	_, err := s.db.Exec(`DELETE FROM shares WHERE token = ?`, token)
+	return err
+}
+
+// CleanupExpired removes expired shares.
+func (s *SQLiteStore) CleanupExpired() (int, error) {
+	res, err := s.db.Exec(`DELETE FROM shares WHERE expires_at <= ?`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+// GetAll returns all shares (for debugging/admin purposes).
+func (s *SQLiteStore) GetAll() (map[string]*ShareData, error) {
+	rows, err := s.db.Query(`SELECT token, data FROM shares`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := make(map[string]*ShareData)
+	for rows.Next() {
+		var token, raw string
+		if err := rows.Scan(&token, &raw); err != nil {
+			return nil, err
+		}
+		data := &ShareData{}
+		if err := json.Unmarshal([]byte(raw), data); err != nil {
+			return nil, err
+		}
+		all[token] = data
+	}
+	return all, rows.Err()
+}
+
+// RecordView atomically registers a view according to the share's scope,
+// within a single transaction, so two concurrent GETs can never both
+// succeed.
+func (s *SQLiteStore) RecordView(token, viewer string) (*ShareData, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRow(`SELECT data FROM shares WHERE token = ?`, token).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ShareData{}
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		return nil, err
+	}
+
+	if data.Scope == ScopeMultiView {
+		if data.MaxViews > 0 && data.ViewCount >= data.MaxViews {
+			return data, ErrAlreadyViewed
+		}
+
+		now := time.Now()
+		data.ViewCount++
+		data.Viewed = true
+		data.ViewedAt = &now
+		recordViewer(data, viewer, now)
+
+		if data.MaxViews > 0 && data.ViewCount >= data.MaxViews {
+			if _, err := tx.Exec(`DELETE FROM shares WHERE token = ?`, token); err != nil {
+				return nil, err
+			}
+			return data, tx.Commit()
+		}
+		if err := txPutShare(tx, token, data); err != nil {
+			return nil, err
+		}
+		return data, tx.Commit()
+	}
+
+	if data.Viewed {
+		return data, ErrAlreadyViewed
+	}
+
+	now := time.Now()
+	data.Viewed = true
+	data.ViewedAt = &now
+	data.ViewCount++
+	recordViewer(data, viewer, now)
+
+	if data.Scope == ScopeWrite {
+		if err := txPutShare(tx, token, data); err != nil {
+			return nil, err
+		}
+		return data, tx.Commit()
+	}
+
+	if _, err := tx.Exec(`DELETE FROM shares WHERE token = ?`, token); err != nil {
+		return nil, err
+	}
+	return data, tx.Commit()
+}
+
+// SetReply stores an encrypted reply against a write-scope share.
+func (s *SQLiteStore) SetReply(token, encryptedReply string) error {
+	data, exists, err := s.Get(token)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrShareNotFound
+	}
+
+	now := time.Now()
+	data.EncryptedReply = encryptedReply
+	data.RepliedAt = &now
+	return s.Add(token, data)
+}
+
+// GetByReplyToken looks up a share by its paired ReplyToken.
+func (s *SQLiteStore) GetByReplyToken(replyToken string) (*ShareData, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT data FROM shares WHERE reply_token = ?`, replyToken).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ShareData{}
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// txPutShare marshals and stores data under token within an open
+// transaction, keeping the reply_token column in sync.
+func txPutShare(tx *sql.Tx, token string, data *ShareData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE shares SET data = ?, reply_token = ? WHERE token = ?`,
+		string(encoded), nullableString(data.ReplyToken), token)
+	return err
+}
+
+// nullableString converts an empty string to a SQL NULL so reply_token
+// comparisons never spuriously match an empty string.
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
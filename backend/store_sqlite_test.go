@@ -0,0 +1,16 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	runShareStoreSuite(t, func() ShareStore {
+		store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "shares.sqlite"))
+		if err != nil {
+			t.Fatalf("NewSQLiteStore: %v", err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runShareStoreSuite exercises the RecordView/SetReply/GetByReplyToken
+// atomicity guarantees every ShareStore implementation promises, so each
+// backend's _test.go file can run the same scenarios against a fresh store
+// instead of duplicating them.
+func runShareStoreSuite(t *testing.T, newStore func() ShareStore) {
+	t.Run("RecordView one_time is exhausted after first view", func(t *testing.T) {
+		store := newStore()
+		token := "token-one-time"
+		if err := store.Add(token, &ShareData{Scope: ScopeOneTime, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+
+		if _, err := store.RecordView(token, ""); err != nil {
+			t.Fatalf("first RecordView: %v", err)
+		}
+
+		if _, err := store.RecordView(token, ""); !errors.Is(err, ErrShareNotFound) {
+			t.Fatalf("second RecordView: got %v, want ErrShareNotFound (one_time shares are deleted on first view)", err)
+		}
+	})
+
+	t.Run("RecordView multi_view stops at max_views", func(t *testing.T) {
+		store := newStore()
+		token := "token-multi-view"
+		if err := store.Add(token, &ShareData{Scope: ScopeMultiView, MaxViews: 2, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := store.RecordView(token, ""); err != nil {
+				t.Fatalf("RecordView #%d: %v", i+1, err)
+			}
+		}
+
+		// The share is deleted once its view budget is exhausted (see
+		// ShareStore.RecordView), so a further attempt sees it as gone
+		// rather than already-viewed.
+		if _, err := store.RecordView(token, ""); !errors.Is(err, ErrShareNotFound) {
+			t.Fatalf("RecordView #3: got %v, want ErrShareNotFound", err)
+		}
+	})
+
+	t.Run("RecordView write scope survives its own view", func(t *testing.T) {
+		store := newStore()
+		token := "token-write"
+		if err := store.Add(token, &ShareData{Scope: ScopeWrite, ReplyToken: "reply-token", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+
+		if _, err := store.RecordView(token, "recipient@example.com"); err != nil {
+			t.Fatalf("RecordView: %v", err)
+		}
+
+		data, exists, err := store.Get(token)
+		if err != nil || !exists {
+			t.Fatalf("Get after view: exists=%v err=%v", exists, err)
+		}
+		if data.ViewedBy["recipient@example.com"].IsZero() {
+			t.Fatalf("ViewedBy not recorded for recipient")
+		}
+
+		if err := store.SetReply(token, "encrypted-reply"); err != nil {
+			t.Fatalf("SetReply: %v", err)
+		}
+
+		replied, err := store.GetByReplyToken("reply-token")
+		if err != nil {
+			t.Fatalf("GetByReplyToken: %v", err)
+		}
+		if replied == nil || replied.EncryptedReply != "encrypted-reply" {
+			t.Fatalf("GetByReplyToken: got %+v, want EncryptedReply=%q", replied, "encrypted-reply")
+		}
+	})
+
+	t.Run("RecordView is atomic under concurrent callers", func(t *testing.T) {
+		store := newStore()
+		token := "token-concurrent"
+		const maxViews = 10
+		if err := store.Add(token, &ShareData{Scope: ScopeMultiView, MaxViews: maxViews, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var succeeded int32
+		var mu sync.Mutex
+		for i := 0; i < maxViews*3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := store.RecordView(token, ""); err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if succeeded != maxViews {
+			t.Fatalf("got %d successful views, want exactly %d (MaxViews)", succeeded, maxViews)
+		}
+	})
+
+	t.Run("SetReply on unknown token returns ErrShareNotFound", func(t *testing.T) {
+		store := newStore()
+		if err := store.SetReply("no-such-token", "x"); !errors.Is(err, ErrShareNotFound) {
+			t.Fatalf("SetReply: got %v, want ErrShareNotFound", err)
+		}
+	})
+
+	t.Run("GetByReplyToken on unknown token returns nil, nil", func(t *testing.T) {
+		store := newStore()
+		data, err := store.GetByReplyToken("no-such-reply-token")
+		if err != nil || data != nil {
+			t.Fatalf("GetByReplyToken: got (%+v, %v), want (nil, nil)", data, err)
+		}
+	})
+}
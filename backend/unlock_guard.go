@@ -0,0 +1,126 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// unlockCredentialTTL is how long an issued unlock token is valid for.
+	unlockCredentialTTL = 2 * time.Minute
+
+	// maxUnlockAttempts caps how many unlock attempts a single token may
+	// make within unlockAttemptWindow, to slow down password brute forcing.
+	maxUnlockAttempts   = 5
+	unlockAttemptWindow = time.Minute
+)
+
+// unlockCredential pairs a single-use retrieval token with its expiry.
+type unlockCredential struct {
+	token     string
+	expiresAt time.Time
+}
+
+// UnlockGuard tracks unlock rate limiting and short-lived retrieval
+// credentials for password-protected shares. This bookkeeping is kept
+// in-process rather than in ShareStore since it is ephemeral (credentials
+// live for unlockCredentialTTL), which means it does not survive a
+// horizontally-scaled deployment: CreateShareHandler refuses to create a
+// password-protected share unless STORE_BACKEND is "memory", where a
+// single instance is guaranteed to hold both the share and its guard.
+type UnlockGuard struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+
+	// credentials holds every outstanding credential per token, not just
+	// the latest one: a password-protected share with multiple recipients
+	// (AllowedRecipients + scope=multi_view) expects each recipient to
+	// unlock and retrieve independently, so one recipient's Issue must not
+	// invalidate another's still-unused credential.
+	credentials map[string][]unlockCredential
+}
+
+// NewUnlockGuard creates a new UnlockGuard.
+func NewUnlockGuard() *UnlockGuard {
+	return &UnlockGuard{
+		attempts:    make(map[string][]time.Time),
+		credentials: make(map[string][]unlockCredential),
+	}
+}
+
+// Allow reports whether token is still under the unlock attempt rate
+// limit, recording the attempt if so. It returns false once a token has
+// been tried maxUnlockAttempts times within unlockAttemptWindow.
+func (g *UnlockGuard) Allow(token string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-unlockAttemptWindow)
+
+	recent := g.attempts[token][:0]
+	for _, t := range g.attempts[token] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= maxUnlockAttempts {
+		g.attempts[token] = recent
+		return false
+	}
+
+	g.attempts[token] = append(recent, now)
+	return true
+}
+
+// Issue generates a short-lived credential proving the caller already
+// supplied the correct unlock password for token. Multiple credentials may
+// be outstanding for the same token at once, one per recipient who has
+// unlocked it.
+func (g *UnlockGuard) Issue(token string) (string, time.Time, error) {
+	credential, err := generateSecureToken(24)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(unlockCredentialTTL)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.credentials[token] = append(pruneExpired(g.credentials[token]), unlockCredential{token: credential, expiresAt: expiresAt})
+
+	return credential, expiresAt, nil
+}
+
+// Consume validates and invalidates a single-use unlock credential
+// previously returned by Issue, leaving any other outstanding credentials
+// for the same token untouched.
+func (g *UnlockGuard) Consume(token, credential string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pending := pruneExpired(g.credentials[token])
+	for i, cred := range pending {
+		if credential != "" && cred.token == credential {
+			g.credentials[token] = append(pending[:i], pending[i+1:]...)
+			return true
+		}
+	}
+
+	g.credentials[token] = pending
+	return false
+}
+
+// pruneExpired drops expired credentials from pending so a token's slice
+// doesn't grow unbounded across repeated unlock attempts.
+func pruneExpired(pending []unlockCredential) []unlockCredential {
+	now := time.Now()
+	live := pending[:0]
+	for _, cred := range pending {
+		if now.Before(cred.expiresAt) {
+			live = append(live, cred)
+		}
+	}
+	return live
+}
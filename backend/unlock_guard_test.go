@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnlockGuard_AllowTripsRateLimit(t *testing.T) {
+	guard := NewUnlockGuard()
+	const token = "rate-limited-token"
+
+	for i := 0; i < maxUnlockAttempts; i++ {
+		if !guard.Allow(token) {
+			t.Fatalf("Allow: attempt %d denied, want allowed (under maxUnlockAttempts)", i+1)
+		}
+	}
+
+	if guard.Allow(token) {
+		t.Fatalf("Allow: attempt %d allowed, want denied (maxUnlockAttempts exceeded)", maxUnlockAttempts+1)
+	}
+}
+
+func TestUnlockGuard_IssueAndConsume(t *testing.T) {
+	guard := NewUnlockGuard()
+	const token = "share-token"
+
+	credential, _, err := guard.Issue(token)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if !guard.Consume(token, credential) {
+		t.Fatal("Consume rejected a credential Issue just returned")
+	}
+	if guard.Consume(token, credential) {
+		t.Fatal("Consume accepted the same credential twice (single-use violated)")
+	}
+}
+
+func TestUnlockGuard_MultipleRecipientsDontOverwriteEachOther(t *testing.T) {
+	guard := NewUnlockGuard()
+	const token = "multi-recipient-token"
+
+	first, _, err := guard.Issue(token)
+	if err != nil {
+		t.Fatalf("Issue (first): %v", err)
+	}
+	second, _, err := guard.Issue(token)
+	if err != nil {
+		t.Fatalf("Issue (second): %v", err)
+	}
+	if first == second {
+		t.Fatal("Issue returned the same credential twice")
+	}
+
+	// Both credentials must still work: issuing the second must not have
+	// evicted the first (the bug this test guards against).
+	if !guard.Consume(token, first) {
+		t.Fatal("Consume rejected the first recipient's credential after a second was issued")
+	}
+	if !guard.Consume(token, second) {
+		t.Fatal("Consume rejected the second recipient's credential")
+	}
+}
+
+func TestUnlockGuard_ConsumeRejectsExpiredCredential(t *testing.T) {
+	guard := NewUnlockGuard()
+	const token = "expiring-token"
+
+	// Reach directly into the unexported state to simulate a credential
+	// that outlived unlockCredentialTTL, rather than sleeping in a test.
+	guard.credentials[token] = []unlockCredential{
+		{token: "stale-credential", expiresAt: time.Now().Add(-time.Second)},
+	}
+
+	if guard.Consume(token, "stale-credential") {
+		t.Fatal("Consume accepted a credential past its expiresAt")
+	}
+}
+
+func TestUnlockGuard_ConsumeRejectsUnknownOrEmptyCredential(t *testing.T) {
+	guard := NewUnlockGuard()
+	const token = "share-token"
+
+	if _, _, err := guard.Issue(token); err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if guard.Consume(token, "") {
+		t.Fatal("Consume accepted an empty credential")
+	}
+	if guard.Consume(token, "not-the-right-credential") {
+		t.Fatal("Consume accepted a credential it never issued")
+	}
+	if guard.Consume("no-such-token", "anything") {
+		t.Fatal("Consume accepted a credential for a token with no outstanding credentials")
+	}
+}